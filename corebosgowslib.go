@@ -21,6 +21,7 @@ package corebosgowslib
 
 import (
 	"bytes"
+	"context"
 	"crypto/md5"
 	"encoding/hex"
 	"encoding/json"
@@ -28,10 +29,16 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
+// errCodeInvalidSession is the coreBOS WS error code returned once sessionName
+// has expired or been invalidated server side.
+const errCodeInvalidSession = "INVALID_SESSIONID"
+
 type cbConnectionType struct {
 	servertime  float64
 	expiretime  string
@@ -47,14 +54,28 @@ var netClient = &http.Client{
 	Timeout: time.Second * 10,
 }
 
+// cbContext holds the connection/session state for one coreBOS WS client.
+// cbConnection and queryResultColumns are mutated by DoLogin/DoQuery and read
+// by the Get* accessors, so both are guarded by mu: a single cbContext can
+// safely be shared by goroutines driving concurrent DoQuery/DoRetrieve calls.
 type cbContext struct {
-	cbConnection       cbConnectionType
-	queryResultColumns map[int]string
+	mu                    sync.RWMutex
+	cbConnection          cbConnectionType
+	queryResultColumns    map[int]string
+	httpClient            *http.Client
+	autoReconnect         bool
+	reconnectUsername     string
+	reconnectKey          string
+	reconnectWithPassword bool
+	reconnectMu           sync.Mutex
 }
 
 func GetCbContext() *cbContext {
 	return &cbContext{
 		queryResultColumns: make(map[int]string),
+		httpClient: &http.Client{
+			Timeout: time.Second * 10,
+		},
 	}
 }
 
@@ -67,45 +88,128 @@ func SetURL(cburl string) {
 	}
 }
 
+// SetHTTPClient lets the caller override the http.Client used by this cbContext,
+// for example to set a custom timeout, proxy, TLS configuration or RoundTripper.
+// If never called, a client with a 10 second timeout is used.
+func (cbCtx *cbContext) SetHTTPClient(client *http.Client) {
+	cbCtx.httpClient = client
+}
+
+// EnableAutoReconnect turns on transparent session renewal for this cbContext.
+// Once enabled, a call that fails with an expired/invalid session will be
+// retried once after a fresh doChallenge+login with the given credentials,
+// and a session that has reached its expireTime is proactively renewed before
+// the next call is made. This is meant for long-running bots/services that
+// hold on to a cbContext across many requests. DoUploadFileMultipart sends
+// its own request and is neither renewed nor retried this way.
+func (cbCtx *cbContext) EnableAutoReconnect(username string, userAccesskey string, withpassword bool) {
+	cbCtx.autoReconnect = true
+	cbCtx.reconnectUsername = username
+	cbCtx.reconnectKey = userAccesskey
+	cbCtx.reconnectWithPassword = withpassword
+}
+
+// sessionExpired reports whether the current session has gone past the
+// expireTime returned by the last challenge/login.
+func (cbCtx *cbContext) sessionExpired() bool {
+	cbCtx.mu.RLock()
+	expiretime := cbCtx.cbConnection.expiretime
+	cbCtx.mu.RUnlock()
+	if expiretime == "" {
+		return false
+	}
+	exp, err := strconv.ParseInt(expiretime, 10, 64)
+	if err != nil {
+		return false
+	}
+	return time.Now().Unix() >= exp
+}
+
+// reLogin redoes the challenge+login handshake, guarded so that concurrent
+// callers racing on the same expired session collapse into a single refresh:
+// a caller that acquires the lock after someone else already renewed the
+// session (staleSessionID no longer matches) is a no-op.
+func (cbCtx *cbContext) reLogin(ctx context.Context, staleSessionID string) error {
+	cbCtx.reconnectMu.Lock()
+	defer cbCtx.reconnectMu.Unlock()
+	if cbCtx.sessionID() != staleSessionID {
+		return nil
+	}
+	ok, err := cbCtx.doLogin(ctx, cbCtx.reconnectUsername, cbCtx.reconnectKey, cbCtx.reconnectWithPassword)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("auto-reconnect: re-login failed")
+	}
+	return nil
+}
+
+// sessionID returns the current session id under the read lock.
+func (cbCtx *cbContext) sessionID() string {
+	cbCtx.mu.RLock()
+	defer cbCtx.mu.RUnlock()
+	return cbCtx.cbConnection.sessionid
+}
+
+// userID returns the current logged in user id under the read lock.
+func (cbCtx *cbContext) userID() string {
+	cbCtx.mu.RLock()
+	defer cbCtx.mu.RUnlock()
+	return cbCtx.cbConnection.userid
+}
+
 func (cbCtx *cbContext) GetServerTime() float64 {
+	cbCtx.mu.RLock()
+	defer cbCtx.mu.RUnlock()
 	return cbCtx.cbConnection.servertime
 }
 
 func (cbCtx *cbContext) GetExpireTime() string {
+	cbCtx.mu.RLock()
+	defer cbCtx.mu.RUnlock()
 	return cbCtx.cbConnection.expiretime
 }
 
 func (cbCtx *cbContext) GetToken() string {
+	cbCtx.mu.RLock()
+	defer cbCtx.mu.RUnlock()
 	return cbCtx.cbConnection.token
 }
 
 func (cbCtx *cbContext) GetServiceUser() string {
+	cbCtx.mu.RLock()
+	defer cbCtx.mu.RUnlock()
 	return cbCtx.cbConnection.serviceuser
 }
 
 func (cbCtx *cbContext) GetServiceKey() string {
+	cbCtx.mu.RLock()
+	defer cbCtx.mu.RUnlock()
 	return cbCtx.cbConnection.servicekey
 }
 
 func (cbCtx *cbContext) GetSessionId() string {
-	return cbCtx.cbConnection.sessionid
+	return cbCtx.sessionID()
 }
 
 func (cbCtx *cbContext) GetUserId() string {
-	return cbCtx.cbConnection.userid
+	return cbCtx.userID()
 }
 
-func (cbCtx *cbContext) doChallenge(username string) (bool, error) {
+func (cbCtx *cbContext) doChallenge(ctx context.Context, username string) (bool, error) {
 	v := url.Values{
 		"operation": {"getchallenge"},
 		"username":  {username},
 	}
-	_, dat, e := cbCtx.docbCall("GET", v)
+	_, dat, e := cbCtx.docbCallContext(ctx, "GET", v)
 	if e == nil && dat["success"] == true {
 		rdo := dat["result"].(map[string]interface{})
+		cbCtx.mu.Lock()
 		cbCtx.cbConnection.servertime = rdo["serverTime"].(float64)
 		cbCtx.cbConnection.expiretime = rdo["expireTime"].(string)
 		cbCtx.cbConnection.token = rdo["token"].(string)
+		cbCtx.mu.Unlock()
 		return true, nil
 	}
 	return false, e
@@ -114,31 +218,45 @@ func (cbCtx *cbContext) doChallenge(username string) (bool, error) {
 // DoLogin connects to coreBOS and tries to validate the given user.
 // true or false will be returned depending on the result of the validation
 func (cbCtx *cbContext) DoLogin(username string, userAccesskey string, withpassword bool) (bool, error) {
-	dc, err := cbCtx.doChallenge(username)
+	return cbCtx.doLogin(context.Background(), username, userAccesskey, withpassword)
+}
+
+// DoLoginContext behaves like DoLogin but honors ctx's deadline and cancellation.
+func (cbCtx *cbContext) DoLoginContext(ctx context.Context, username string, userAccesskey string, withpassword bool) (bool, error) {
+	return cbCtx.doLogin(ctx, username, userAccesskey, withpassword)
+}
+
+func (cbCtx *cbContext) doLogin(ctx context.Context, username string, userAccesskey string, withpassword bool) (bool, error) {
+	dc, err := cbCtx.doChallenge(ctx, username)
 	if dc == false {
 		return false, err
 	}
+	cbCtx.mu.RLock()
+	token := cbCtx.cbConnection.token
+	cbCtx.mu.RUnlock()
 	v := url.Values{
 		"operation": {"login"},
 		"username":  {username},
 	}
 	if withpassword == true {
-		v.Set("accessKey", cbCtx.cbConnection.token+userAccesskey)
+		v.Set("accessKey", token+userAccesskey)
 	} else {
-		v.Set("accessKey", getMD5Hash(cbCtx.cbConnection.token+userAccesskey))
+		v.Set("accessKey", getMD5Hash(token+userAccesskey))
 	}
-	_, dat, e := cbCtx.docbCall("POST", v)
+	_, dat, e := cbCtx.docbCallContext(ctx, "POST", v)
 	if e == nil && dat["success"] == true {
 		rdo := dat["result"].(map[string]interface{})
+		cbCtx.mu.Lock()
 		cbCtx.cbConnection.serviceuser = username
 		cbCtx.cbConnection.servicekey = userAccesskey
 		cbCtx.cbConnection.sessionid = rdo["sessionName"].(string)
 		cbCtx.cbConnection.userid = rdo["userId"].(string)
+		cbCtx.mu.Unlock()
 		return true, nil
 	}
 	if e == nil {
 		wserr := dat["error"].(map[string]interface{})
-		return false, errors.New(wserr["code"].(string) + ": " + wserr["message"].(string))
+		return false, wsErrorFrom("login", wserr)
 	}
 	return false, e
 }
@@ -146,55 +264,82 @@ func (cbCtx *cbContext) DoLogin(username string, userAccesskey string, withpassw
 // DoLogout disconnects from coreBOS.
 // returns true or false depending on the result
 func (cbCtx *cbContext) DoLogout() (bool, error) {
+	return cbCtx.doLogout(context.Background())
+}
+
+// DoLogoutContext behaves like DoLogout but honors ctx's deadline and cancellation.
+func (cbCtx *cbContext) DoLogoutContext(ctx context.Context) (bool, error) {
+	return cbCtx.doLogout(ctx)
+}
+
+func (cbCtx *cbContext) doLogout(ctx context.Context) (bool, error) {
 	v := url.Values{
 		"operation":   {"logout"},
-		"sessionName": {cbCtx.cbConnection.sessionid},
+		"sessionName": {cbCtx.sessionID()},
 	}
-	_, dat, e := cbCtx.docbCall("POST", v)
+	_, dat, e := cbCtx.docbCallContext(ctx, "POST", v)
 	if e == nil && dat["success"] == true {
 		return true, nil
 	}
 	if e == nil {
 		wserr := dat["error"].(map[string]interface{})
-		return false, errors.New(wserr["code"].(string) + ": " + wserr["message"].(string))
+		return false, wsErrorFrom("logout", wserr)
 	}
 	return false, e
 }
 
-// DoQuery retrieves records using a specilized query language
-// returns the set of records and columsn obtained from the query
-func (cbCtx *cbContext) DoQuery(query string) (interface{}, error) {
+// DoQuery retrieves records using a specilized query language.
+// It returns the set of records obtained from the query together with their
+// column names, as a value local to this call, so that parallel queries on
+// the same cbContext never clobber each other's column list.
+func (cbCtx *cbContext) DoQuery(query string) (interface{}, map[int]string, error) {
+	return cbCtx.doQuery(context.Background(), query)
+}
+
+// DoQueryContext behaves like DoQuery but honors ctx's deadline and cancellation.
+func (cbCtx *cbContext) DoQueryContext(ctx context.Context, query string) (interface{}, map[int]string, error) {
+	return cbCtx.doQuery(ctx, query)
+}
+
+func (cbCtx *cbContext) doQuery(ctx context.Context, query string) (interface{}, map[int]string, error) {
 	// query must end in ; so we make sure
 	q := strings.Trim(query, " ;") + ";"
 	v := url.Values{
 		"operation":   {"query"},
-		"sessionName": {cbCtx.cbConnection.sessionid},
+		"sessionName": {cbCtx.sessionID()},
 		"query":       {q},
 	}
-	_, dat, e := cbCtx.docbCall("GET", v)
+	_, dat, e := cbCtx.docbCallContext(ctx, "GET", v)
 	if e == nil && dat["success"] == true {
 		rdos := dat["result"].([]interface{})
+		columns := make(map[int]string)
 		if len(rdos) > 0 {
 			idx := 0
 			for col := range rdos[0].(map[string]interface{}) {
-				cbCtx.queryResultColumns[idx] = col
+				columns[idx] = col
 				idx++
 			}
-		} else {
-			cbCtx.queryResultColumns = make(map[int]string)
 		}
-		return dat["result"], nil
+		cbCtx.mu.Lock()
+		cbCtx.queryResultColumns = columns
+		cbCtx.mu.Unlock()
+		return dat["result"], columns, nil
 	}
 	empty := make(map[string]interface{})
 	if e == nil {
 		wserr := dat["error"].(map[string]interface{})
-		return empty, errors.New(wserr["code"].(string) + ": " + wserr["message"].(string))
+		return empty, nil, wsErrorFrom("query", wserr)
 	}
-	return empty, e
+	return empty, nil, e
 }
 
-// GetResultColumns returns the column names of the last query
+// GetResultColumns returns the column names of the last query run on this
+// cbContext. Prefer the map[int]string returned directly by DoQuery when
+// running concurrent queries, since this reflects whichever query last wrote
+// it.
 func (cbCtx *cbContext) GetResultColumns() map[int]string {
+	cbCtx.mu.RLock()
+	defer cbCtx.mu.RUnlock()
 	return cbCtx.queryResultColumns
 }
 
@@ -202,13 +347,22 @@ func (cbCtx *cbContext) GetResultColumns() map[int]string {
 // filtered by those that contain a field of the given type(s). If no type is
 // given all accessible modules will be returned
 func (cbCtx *cbContext) DoListTypes(fieldTypeList []string) (map[string]string, error) {
+	return cbCtx.doListTypes(context.Background(), fieldTypeList)
+}
+
+// DoListTypesContext behaves like DoListTypes but honors ctx's deadline and cancellation.
+func (cbCtx *cbContext) DoListTypesContext(ctx context.Context, fieldTypeList []string) (map[string]string, error) {
+	return cbCtx.doListTypes(ctx, fieldTypeList)
+}
+
+func (cbCtx *cbContext) doListTypes(ctx context.Context, fieldTypeList []string) (map[string]string, error) {
 	ftl, _ := json.Marshal(fieldTypeList)
 	v := url.Values{
 		"operation":     {"listtypes"},
-		"sessionName":   {cbCtx.cbConnection.sessionid},
+		"sessionName":   {cbCtx.sessionID()},
 		"fieldTypeList": {string(ftl)},
 	}
-	_, dat, e := cbCtx.docbCall("GET", v)
+	_, dat, e := cbCtx.docbCallContext(ctx, "GET", v)
 	if e == nil && dat["success"] == true {
 		rdos := dat["result"].(map[string]interface{})
 		types := make(map[string]string)
@@ -221,71 +375,98 @@ func (cbCtx *cbContext) DoListTypes(fieldTypeList []string) (map[string]string,
 	empty := make(map[string]string)
 	if e == nil {
 		wserr := dat["error"].(map[string]interface{})
-		return empty, errors.New(wserr["code"].(string) + ": " + wserr["message"].(string))
+		return empty, wsErrorFrom("listtypes", wserr)
 	}
 	return empty, e
 }
 
 // DoDescribe gets all the details of a Module's permissions and fields
 func (cbCtx *cbContext) DoDescribe(module string) (map[string]interface{}, error) {
+	return cbCtx.doDescribe(context.Background(), module)
+}
+
+// DoDescribeContext behaves like DoDescribe but honors ctx's deadline and cancellation.
+func (cbCtx *cbContext) DoDescribeContext(ctx context.Context, module string) (map[string]interface{}, error) {
+	return cbCtx.doDescribe(ctx, module)
+}
+
+func (cbCtx *cbContext) doDescribe(ctx context.Context, module string) (map[string]interface{}, error) {
 	v := url.Values{
 		"operation":   {"describe"},
-		"sessionName": {cbCtx.cbConnection.sessionid},
+		"sessionName": {cbCtx.sessionID()},
 		"elementType": {module},
 	}
-	_, dat, e := cbCtx.docbCall("GET", v)
+	_, dat, e := cbCtx.docbCallContext(ctx, "GET", v)
 	if e == nil && dat["success"] == true {
 		return dat["result"].(map[string]interface{}), nil
 	}
 	empty := make(map[string]interface{})
 	if e == nil {
 		wserr := dat["error"].(map[string]interface{})
-		return empty, errors.New(wserr["code"].(string) + ": " + wserr["message"].(string))
+		return empty, wsErrorFrom("describe", wserr)
 	}
 	return empty, e
 }
 
 // DoRetrieve details of a record.
 func (cbCtx *cbContext) DoRetrieve(record string) (map[string]interface{}, error) {
+	return cbCtx.doRetrieve(context.Background(), record)
+}
+
+// DoRetrieveContext behaves like DoRetrieve but honors ctx's deadline and cancellation.
+func (cbCtx *cbContext) DoRetrieveContext(ctx context.Context, record string) (map[string]interface{}, error) {
+	return cbCtx.doRetrieve(ctx, record)
+}
+
+func (cbCtx *cbContext) doRetrieve(ctx context.Context, record string) (map[string]interface{}, error) {
 	v := url.Values{
 		"operation":   {"retrieve"},
-		"sessionName": {cbCtx.cbConnection.sessionid},
+		"sessionName": {cbCtx.sessionID()},
 		"id":          {record},
 	}
-	_, dat, e := cbCtx.docbCall("GET", v)
+	_, dat, e := cbCtx.docbCallContext(ctx, "GET", v)
 	if e == nil && dat["success"] == true {
 		return dat["result"].(map[string]interface{}), nil
 	}
 	empty := make(map[string]interface{})
 	if e == nil {
 		wserr := dat["error"].(map[string]interface{})
-		return empty, errors.New(wserr["code"].(string) + ": " + wserr["message"].(string))
+		return empty, wsErrorFrom("retrieve", wserr)
 	}
 	return empty, e
 }
 
 // DoCreate adds new records to the application
 func (cbCtx *cbContext) DoCreate(module string, valuemap map[string]interface{}) (map[string]interface{}, error) {
+	return cbCtx.doCreate(context.Background(), module, valuemap)
+}
+
+// DoCreateContext behaves like DoCreate but honors ctx's deadline and cancellation.
+func (cbCtx *cbContext) DoCreateContext(ctx context.Context, module string, valuemap map[string]interface{}) (map[string]interface{}, error) {
+	return cbCtx.doCreate(ctx, module, valuemap)
+}
+
+func (cbCtx *cbContext) doCreate(ctx context.Context, module string, valuemap map[string]interface{}) (map[string]interface{}, error) {
 	// Assign record to logged in user if not specified
 	_, exists := valuemap["assigned_user_id"]
 	if exists == false {
-		valuemap["assigned_user_id"] = cbCtx.cbConnection.userid
+		valuemap["assigned_user_id"] = cbCtx.userID()
 	}
 	vals, _ := json.Marshal(valuemap)
 	v := url.Values{
 		"operation":   {"create"},
-		"sessionName": {cbCtx.cbConnection.sessionid},
+		"sessionName": {cbCtx.sessionID()},
 		"elementType": {module},
 		"element":     {string(vals)},
 	}
-	_, dat, e := cbCtx.docbCall("POST", v)
+	_, dat, e := cbCtx.docbCallContext(ctx, "POST", v)
 	if e == nil && dat["success"] == true {
 		return dat["result"].(map[string]interface{}), nil
 	}
 	empty := make(map[string]interface{})
 	if e == nil {
 		wserr := dat["error"].(map[string]interface{})
-		return empty, errors.New(wserr["code"].(string) + ": " + wserr["message"].(string))
+		return empty, wsErrorFrom("create", wserr)
 	}
 	return empty, e
 }
@@ -293,26 +474,35 @@ func (cbCtx *cbContext) DoCreate(module string, valuemap map[string]interface{})
 // DoUpdate updates the given record with the new values.
 // ALL mandatory fields MUST be present
 func (cbCtx *cbContext) DoUpdate(module string, valuemap map[string]interface{}) (map[string]interface{}, error) {
+	return cbCtx.doUpdate(context.Background(), module, valuemap)
+}
+
+// DoUpdateContext behaves like DoUpdate but honors ctx's deadline and cancellation.
+func (cbCtx *cbContext) DoUpdateContext(ctx context.Context, module string, valuemap map[string]interface{}) (map[string]interface{}, error) {
+	return cbCtx.doUpdate(ctx, module, valuemap)
+}
+
+func (cbCtx *cbContext) doUpdate(ctx context.Context, module string, valuemap map[string]interface{}) (map[string]interface{}, error) {
 	// Assign record to logged in user if not specified
 	_, exists := valuemap["assigned_user_id"]
 	if exists == false {
-		valuemap["assigned_user_id"] = cbCtx.cbConnection.userid
+		valuemap["assigned_user_id"] = cbCtx.userID()
 	}
 	vals, _ := json.Marshal(valuemap)
 	v := url.Values{
 		"operation":   {"update"},
-		"sessionName": {cbCtx.cbConnection.sessionid},
+		"sessionName": {cbCtx.sessionID()},
 		"elementType": {module},
 		"element":     {string(vals)},
 	}
-	_, dat, e := cbCtx.docbCall("POST", v)
+	_, dat, e := cbCtx.docbCallContext(ctx, "POST", v)
 	if e == nil && dat["success"] == true {
 		return dat["result"].(map[string]interface{}), nil
 	}
 	empty := make(map[string]interface{})
 	if e == nil {
 		wserr := dat["error"].(map[string]interface{})
-		return empty, errors.New(wserr["code"].(string) + ": " + wserr["message"].(string))
+		return empty, wsErrorFrom("update", wserr)
 	}
 	return empty, e
 }
@@ -320,38 +510,56 @@ func (cbCtx *cbContext) DoUpdate(module string, valuemap map[string]interface{})
 // DoRevise updates the given record with the new values.
 // mandatory fields do not have to be present
 func (cbCtx *cbContext) DoRevise(module string, valuemap map[string]interface{}) (map[string]interface{}, error) {
+	return cbCtx.doRevise(context.Background(), module, valuemap)
+}
+
+// DoReviseContext behaves like DoRevise but honors ctx's deadline and cancellation.
+func (cbCtx *cbContext) DoReviseContext(ctx context.Context, module string, valuemap map[string]interface{}) (map[string]interface{}, error) {
+	return cbCtx.doRevise(ctx, module, valuemap)
+}
+
+func (cbCtx *cbContext) doRevise(ctx context.Context, module string, valuemap map[string]interface{}) (map[string]interface{}, error) {
 	// Assign record to logged in user if not specified
 	_, exists := valuemap["assigned_user_id"]
 	if exists == false {
-		valuemap["assigned_user_id"] = cbCtx.cbConnection.userid
+		valuemap["assigned_user_id"] = cbCtx.userID()
 	}
 	vals, _ := json.Marshal(valuemap)
 	v := url.Values{
 		"operation":   {"revise"},
-		"sessionName": {cbCtx.cbConnection.sessionid},
+		"sessionName": {cbCtx.sessionID()},
 		"elementType": {module},
 		"element":     {string(vals)},
 	}
-	_, dat, e := cbCtx.docbCall("POST", v)
+	_, dat, e := cbCtx.docbCallContext(ctx, "POST", v)
 	if e == nil && dat["success"] == true {
 		return dat["result"].(map[string]interface{}), nil
 	}
 	empty := make(map[string]interface{})
 	if e == nil {
 		wserr := dat["error"].(map[string]interface{})
-		return empty, errors.New(wserr["code"].(string) + ": " + wserr["message"].(string))
+		return empty, wsErrorFrom("revise", wserr)
 	}
 	return empty, e
 }
 
 // DoDelete eliminates the record with the given ID
 func (cbCtx *cbContext) DoDelete(record string) (bool, error) {
+	return cbCtx.doDelete(context.Background(), record)
+}
+
+// DoDeleteContext behaves like DoDelete but honors ctx's deadline and cancellation.
+func (cbCtx *cbContext) DoDeleteContext(ctx context.Context, record string) (bool, error) {
+	return cbCtx.doDelete(ctx, record)
+}
+
+func (cbCtx *cbContext) doDelete(ctx context.Context, record string) (bool, error) {
 	v := url.Values{
 		"operation":   {"delete"},
-		"sessionName": {cbCtx.cbConnection.sessionid},
+		"sessionName": {cbCtx.sessionID()},
 		"id":          {record},
 	}
-	_, dat, e := cbCtx.docbCall("POST", v)
+	_, dat, e := cbCtx.docbCallContext(ctx, "POST", v)
 	if e == nil && dat["success"] == true {
 		rdo := dat["result"].(map[string]interface{})
 		if rdo["status"].(string) == "successful" {
@@ -362,7 +570,7 @@ func (cbCtx *cbContext) DoDelete(record string) (bool, error) {
 	}
 	if e == nil {
 		wserr := dat["error"].(map[string]interface{})
-		return false, errors.New(wserr["code"].(string) + ": " + wserr["message"].(string))
+		return false, wsErrorFrom("delete", wserr)
 	}
 	return false, e
 }
@@ -372,39 +580,57 @@ func (cbCtx *cbContext) DoDelete(record string) (bool, error) {
 // param Object type null or parameter values to method
 // param String POST/GET
 func (cbCtx *cbContext) DoInvoke(method string, params map[string]interface{}, typeofcall string) (map[string]interface{}, error) {
+	return cbCtx.doInvoke(context.Background(), method, params, typeofcall)
+}
+
+// DoInvokeContext behaves like DoInvoke but honors ctx's deadline and cancellation.
+func (cbCtx *cbContext) DoInvokeContext(ctx context.Context, method string, params map[string]interface{}, typeofcall string) (map[string]interface{}, error) {
+	return cbCtx.doInvoke(ctx, method, params, typeofcall)
+}
+
+func (cbCtx *cbContext) doInvoke(ctx context.Context, method string, params map[string]interface{}, typeofcall string) (map[string]interface{}, error) {
 	v := url.Values{
 		"operation":   {method},
-		"sessionName": {cbCtx.cbConnection.sessionid},
+		"sessionName": {cbCtx.sessionID()},
 	}
 	for idx, val := range params {
 		if v.Get(idx) == "" {
 			v.Set(idx, val.(string))
 		}
 	}
-	_, dat, e := cbCtx.docbCall(typeofcall, v)
+	_, dat, e := cbCtx.docbCallContext(ctx, typeofcall, v)
 	if e == nil && dat["success"] == true {
 		return dat["result"].(map[string]interface{}), nil
 	}
 	empty := make(map[string]interface{})
 	if e == nil {
 		wserr := dat["error"].(map[string]interface{})
-		return empty, errors.New(wserr["code"].(string) + ": " + wserr["message"].(string))
+		return empty, wsErrorFrom(method, wserr)
 	}
 	return empty, e
 }
 
 // DoGetRelatedRecords will retrieve all related records of the given record that belong to the given related module
 func (cbCtx *cbContext) DoGetRelatedRecords(record string, module string, relatedModule string, queryParameters map[string]interface{}) ([]interface{}, error) {
+	return cbCtx.doGetRelatedRecords(context.Background(), record, module, relatedModule, queryParameters)
+}
+
+// DoGetRelatedRecordsContext behaves like DoGetRelatedRecords but honors ctx's deadline and cancellation.
+func (cbCtx *cbContext) DoGetRelatedRecordsContext(ctx context.Context, record string, module string, relatedModule string, queryParameters map[string]interface{}) ([]interface{}, error) {
+	return cbCtx.doGetRelatedRecords(ctx, record, module, relatedModule, queryParameters)
+}
+
+func (cbCtx *cbContext) doGetRelatedRecords(ctx context.Context, record string, module string, relatedModule string, queryParameters map[string]interface{}) ([]interface{}, error) {
 	params, _ := json.Marshal(queryParameters)
 	v := url.Values{
 		"operation":       {"getRelatedRecords"},
-		"sessionName":     {cbCtx.cbConnection.sessionid},
+		"sessionName":     {cbCtx.sessionID()},
 		"id":              {record},
 		"module":          {module},
 		"relatedModule":   {relatedModule},
 		"queryParameters": {string(params)},
 	}
-	_, dat, e := cbCtx.docbCall("POST", v)
+	_, dat, e := cbCtx.docbCallContext(ctx, "POST", v)
 	if e == nil && dat["success"] == true {
 		rdo := dat["result"].(map[string]interface{})
 		return rdo["records"].([]interface{}), nil
@@ -412,7 +638,7 @@ func (cbCtx *cbContext) DoGetRelatedRecords(record string, module string, relate
 	var empty []interface{}
 	if e == nil {
 		wserr := dat["error"].(map[string]interface{})
-		return empty, errors.New(wserr["code"].(string) + ": " + wserr["message"].(string))
+		return empty, wsErrorFrom("getRelatedRecords", wserr)
 	}
 	return empty, e
 }
@@ -421,40 +647,58 @@ func (cbCtx *cbContext) DoGetRelatedRecords(record string, module string, relate
 // param relateThisID string ID of record we want to be related with other records
 // param withTheseIds array of IDs to relate to the first parameter
 func (cbCtx *cbContext) DoSetRelated(relateThisID string, withTheseIds []string) (bool, error) {
+	return cbCtx.doSetRelated(context.Background(), relateThisID, withTheseIds)
+}
+
+// DoSetRelatedContext behaves like DoSetRelated but honors ctx's deadline and cancellation.
+func (cbCtx *cbContext) DoSetRelatedContext(ctx context.Context, relateThisID string, withTheseIds []string) (bool, error) {
+	return cbCtx.doSetRelated(ctx, relateThisID, withTheseIds)
+}
+
+func (cbCtx *cbContext) doSetRelated(ctx context.Context, relateThisID string, withTheseIds []string) (bool, error) {
 	params, _ := json.Marshal(withTheseIds)
 	v := url.Values{
 		"operation":      {"SetRelation"},
-		"sessionName":    {cbCtx.cbConnection.sessionid},
+		"sessionName":    {cbCtx.sessionID()},
 		"relate_this_id": {relateThisID},
 		"with_these_ids": {string(params)},
 	}
-	_, dat, e := cbCtx.docbCall("POST", v)
+	_, dat, e := cbCtx.docbCallContext(ctx, "POST", v)
 	if e == nil && dat["success"] == true {
 		return dat["result"].(bool), nil
 	}
 	if e == nil {
 		wserr := dat["error"].(map[string]interface{})
-		return false, errors.New(wserr["code"].(string) + ": " + wserr["message"].(string))
+		return false, wsErrorFrom("SetRelation", wserr)
 	}
 	return false, e
 }
 
 // DoLoginPage get HTML for the Login page
 func (cbCtx *cbContext) DoLoginPage(template string, language string, csrf string) (string, error) {
+	return cbCtx.doLoginPage(context.Background(), template, language, csrf)
+}
+
+// DoLoginPageContext behaves like DoLoginPage but honors ctx's deadline and cancellation.
+func (cbCtx *cbContext) DoLoginPageContext(ctx context.Context, template string, language string, csrf string) (string, error) {
+	return cbCtx.doLoginPage(ctx, template, language, csrf)
+}
+
+func (cbCtx *cbContext) doLoginPage(ctx context.Context, template string, language string, csrf string) (string, error) {
 	v := url.Values{
 		"operation":   {"getLoginPage"},
-		"sessionName": {cbCtx.cbConnection.sessionid},
+		"sessionName": {cbCtx.sessionID()},
 		"template":    {template},
 		"language":    {language},
 		"csrf":        {csrf},
 	}
-	_, dat, e := cbCtx.docbCall("GET", v)
+	_, dat, e := cbCtx.docbCallContext(ctx, "GET", v)
 	if e == nil && dat["success"] == true {
 		return dat["result"].(string), nil
 	}
 	if e == nil {
 		wserr := dat["error"].(map[string]interface{})
-		return "", errors.New(wserr["code"].(string) + ": " + wserr["message"].(string))
+		return "", wsErrorFrom("getLoginPage", wserr)
 	}
 	return "", e
 }
@@ -462,26 +706,81 @@ func (cbCtx *cbContext) DoLoginPage(template string, language string, csrf strin
 ///////////////////////////////////////////////////////
 
 func (cbCtx *cbContext) docbCall(typeofcall string, params url.Values) (bool, map[string]interface{}, error) {
+	return cbCtx.docbCallContext(context.Background(), typeofcall, params)
+}
+
+func (cbCtx *cbContext) docbCallContext(ctx context.Context, typeofcall string, params url.Values) (bool, map[string]interface{}, error) {
+	return cbCtx.docbCallAttempt(ctx, typeofcall, params, false)
+}
+
+// isSessionCall tells apart calls that carry a sessionName (and so can be
+// transparently retried after a re-login) from the challenge/login calls
+// that establish the session in the first place.
+func isSessionCall(params url.Values) bool {
+	op := params.Get("operation")
+	return op != "login" && op != "getchallenge"
+}
+
+// isSessionExpiredResponse reports whether dat is a coreBOS WS error response
+// caused by an expired/invalid session.
+func isSessionExpiredResponse(dat map[string]interface{}) bool {
+	if success, ok := dat["success"].(bool); !ok || success {
+		return false
+	}
+	wserr, ok := dat["error"].(map[string]interface{})
+	if !ok {
+		return false
+	}
+	code, _ := wserr["code"].(string)
+	return code == errCodeInvalidSession
+}
+
+func (cbCtx *cbContext) docbCallAttempt(ctx context.Context, typeofcall string, params url.Values, retried bool) (bool, map[string]interface{}, error) {
 	empty := make(map[string]interface{})
-	var resp *http.Response
+	sessionCall := isSessionCall(params)
+	if !retried && sessionCall && cbCtx.autoReconnect && cbCtx.sessionExpired() {
+		if rerr := cbCtx.reLogin(ctx, params.Get("sessionName")); rerr == nil {
+			params.Set("sessionName", cbCtx.sessionID())
+		}
+	}
+	client := cbCtx.httpClient
+	if client == nil {
+		client = netClient
+	}
+	var req *http.Request
 	var err error
-	body := bytes.NewBufferString(params.Encode())
 	if strings.ToUpper(typeofcall[0:]) == "POST" {
-		resp, err = netClient.Post(cbURL, "application/x-www-form-urlencoded", body)
+		req, err = http.NewRequestWithContext(ctx, "POST", cbURL, bytes.NewBufferString(params.Encode()))
+		if err == nil {
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
 	} else {
-		resp, err = netClient.Get(cbURL + "?" + body.String())
+		req, err = http.NewRequestWithContext(ctx, "GET", cbURL+"?"+params.Encode(), nil)
 	}
 	if err != nil {
 		return false, empty, err
 	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return false, empty, err
+	}
 	b, err := ioutil.ReadAll(resp.Body)
 	resp.Body.Close()
 	if err != nil {
 		return false, empty, err
 	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return false, empty, newHTTPError(params.Get("operation"), resp.StatusCode, b)
+	}
 
 	var dat map[string]interface{}
 	e := json.Unmarshal(b, &dat)
+	if e == nil && !retried && sessionCall && cbCtx.autoReconnect && isSessionExpiredResponse(dat) {
+		if rerr := cbCtx.reLogin(ctx, params.Get("sessionName")); rerr == nil {
+			params.Set("sessionName", cbCtx.sessionID())
+			return cbCtx.docbCallAttempt(ctx, typeofcall, params, true)
+		}
+	}
 	return true, dat, e
 }
 
@@ -0,0 +1,76 @@
+package corebosgowslib
+
+import "fmt"
+
+// WSError represents an error response returned by the coreBOS WS API
+// itself (dat["error"]), as opposed to a transport-level failure (see
+// HTTPError). Code is the machine-readable error code the server sent
+// (e.g. "INVALID_SESSIONID"); Operation is the WS operation that produced
+// it, for context in logs.
+type WSError struct {
+	Code       string
+	Message    string
+	Operation  string
+	HTTPStatus int
+}
+
+func (e *WSError) Error() string {
+	if e.Operation != "" {
+		return fmt.Sprintf("%s: %s: %s", e.Operation, e.Code, e.Message)
+	}
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+// Is lets errors.Is match a *WSError against one of the Err* sentinels below
+// by comparing Code, so callers can write errors.Is(err, ErrAuthFailed)
+// instead of string-matching a raw error code.
+func (e *WSError) Is(target error) bool {
+	t, ok := target.(*WSError)
+	if !ok || t.Code == "" {
+		return false
+	}
+	return e.Code == t.Code
+}
+
+// Sentinel WSErrors for the coreBOS WS error codes callers most commonly
+// need to branch on with errors.Is.
+var (
+	ErrSessionExpired = &WSError{Code: errCodeInvalidSession}
+	ErrAuthFailed     = &WSError{Code: "AUTHENTICATION_FAILED"}
+	ErrAccessDenied   = &WSError{Code: "ACCESS_DENIED"}
+	ErrNotFound       = &WSError{Code: "NO_RESULTS_FOUND"}
+)
+
+// wsErrorFrom builds the *WSError for a decoded coreBOS WS error payload
+// (dat["error"]), tagging it with the operation that produced it.
+func wsErrorFrom(operation string, wserr map[string]interface{}) error {
+	code, _ := wserr["code"].(string)
+	message, _ := wserr["message"].(string)
+	return &WSError{Code: code, Message: message, Operation: operation}
+}
+
+// maxHTTPErrorBodySnippet bounds how much of a non-2xx response body
+// HTTPError keeps around for diagnostics.
+const maxHTTPErrorBodySnippet = 256
+
+// HTTPError is returned when the coreBOS endpoint answers with a non-2xx
+// HTTP status, meaning the body is not a WS success/error payload at all
+// (e.g. a proxy error page or an unrelated web server response) and so
+// cannot be json.Unmarshal'd as one.
+type HTTPError struct {
+	StatusCode int
+	Operation  string
+	Body       string
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("%s: unexpected HTTP status %d: %s", e.Operation, e.StatusCode, e.Body)
+}
+
+func newHTTPError(operation string, statusCode int, body []byte) *HTTPError {
+	snippet := string(body)
+	if len(snippet) > maxHTTPErrorBodySnippet {
+		snippet = snippet[:maxHTTPErrorBodySnippet]
+	}
+	return &HTTPError{StatusCode: statusCode, Operation: operation, Body: snippet}
+}
@@ -0,0 +1,108 @@
+package corebosgowslib
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// defaultQueryPageSize is the LIMIT used by DoQueryAll/DoQueryIter for each
+// page fetched from the server.
+const defaultQueryPageSize = 100
+
+// limitClauseRe matches a trailing "limit n" or "limit n,m" clause so
+// DoQueryAll/DoQueryIter can replace it with their own paging window.
+var limitClauseRe = regexp.MustCompile(`(?i)\s+limit\s+\d+(\s*,\s*\d+)?\s*$`)
+
+// stripLimit removes any existing LIMIT clause and trailing ";" from query,
+// so a fresh one can be appended for paging.
+func stripLimit(query string) string {
+	q := strings.TrimRight(strings.TrimSpace(query), "; ")
+	return strings.TrimSpace(limitClauseRe.ReplaceAllString(q, ""))
+}
+
+// Record is a single row as returned by DoQuery/DoQueryIter.
+type Record = map[string]interface{}
+
+// DoQueryAll runs query the same way DoQuery does, but transparently pages
+// through the full result set with LIMIT/OFFSET clauses (replacing any LIMIT
+// already present in query) until an empty page comes back, returning every
+// row concatenated together. Use DoQueryIter instead when the result set is
+// too large to hold in memory at once.
+func (cbCtx *cbContext) DoQueryAll(query string) ([]interface{}, error) {
+	return cbCtx.doQueryAll(context.Background(), query)
+}
+
+// DoQueryAllContext behaves like DoQueryAll but honors ctx's deadline and cancellation.
+func (cbCtx *cbContext) DoQueryAllContext(ctx context.Context, query string) ([]interface{}, error) {
+	return cbCtx.doQueryAll(ctx, query)
+}
+
+func (cbCtx *cbContext) doQueryAll(ctx context.Context, query string) ([]interface{}, error) {
+	base := stripLimit(query)
+	var all []interface{}
+	for offset := 0; ; offset += defaultQueryPageSize {
+		page := fmt.Sprintf("%s limit %d,%d", base, offset, defaultQueryPageSize)
+		result, _, err := cbCtx.doQuery(ctx, page)
+		if err != nil {
+			return all, err
+		}
+		rows, _ := result.([]interface{})
+		all = append(all, rows...)
+		if len(rows) == 0 {
+			return all, nil
+		}
+	}
+}
+
+// DoQueryIter behaves like DoQueryAll but streams rows one at a time over the
+// returned channel instead of buffering the whole result set in memory. The
+// error channel receives at most one value and is closed, together with the
+// record channel, once paging stops. Cancelling the ctx given to
+// DoQueryIterContext stops paging early without leaking the goroutine driving
+// the iteration; DoQueryIter itself runs under context.Background and cannot
+// be stopped early.
+func (cbCtx *cbContext) DoQueryIter(query string) (<-chan Record, <-chan error) {
+	return cbCtx.doQueryIter(context.Background(), query)
+}
+
+// DoQueryIterContext behaves like DoQueryIter but honors ctx's deadline and cancellation.
+func (cbCtx *cbContext) DoQueryIterContext(ctx context.Context, query string) (<-chan Record, <-chan error) {
+	return cbCtx.doQueryIter(ctx, query)
+}
+
+func (cbCtx *cbContext) doQueryIter(ctx context.Context, query string) (<-chan Record, <-chan error) {
+	records := make(chan Record)
+	errs := make(chan error, 1)
+	base := stripLimit(query)
+	go func() {
+		defer close(records)
+		defer close(errs)
+		for offset := 0; ; offset += defaultQueryPageSize {
+			page := fmt.Sprintf("%s limit %d,%d", base, offset, defaultQueryPageSize)
+			result, _, err := cbCtx.doQuery(ctx, page)
+			if err != nil {
+				errs <- err
+				return
+			}
+			rows, _ := result.([]interface{})
+			for _, row := range rows {
+				rec, ok := row.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				select {
+				case records <- rec:
+				case <-ctx.Done():
+					errs <- ctx.Err()
+					return
+				}
+			}
+			if len(rows) == 0 {
+				return
+			}
+		}
+	}()
+	return records, errs
+}
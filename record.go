@@ -0,0 +1,325 @@
+package corebosgowslib
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// cbTimeLayouts are the date/datetime formats coreBOS sends and accepts over
+// the WS API, tried in order when decoding into a time.Time field.
+var cbTimeLayouts = []string{"2006-01-02 15:04:05", "2006-01-02"}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// DoRetrieveInto retrieves record like DoRetrieve and decodes the result into
+// out, which must be a pointer to a struct. Fields are matched using the
+// `corebos` struct tag, falling back to `json` and finally the field name.
+func (cbCtx *cbContext) DoRetrieveInto(record string, out interface{}) error {
+	return cbCtx.doRetrieveInto(context.Background(), record, out)
+}
+
+// DoRetrieveIntoContext behaves like DoRetrieveInto but honors ctx's deadline and cancellation.
+func (cbCtx *cbContext) DoRetrieveIntoContext(ctx context.Context, record string, out interface{}) error {
+	return cbCtx.doRetrieveInto(ctx, record, out)
+}
+
+func (cbCtx *cbContext) doRetrieveInto(ctx context.Context, record string, out interface{}) error {
+	rec, err := cbCtx.doRetrieve(ctx, record)
+	if err != nil {
+		return err
+	}
+	return decodeInto(rec, out)
+}
+
+// DoQueryInto runs query like DoQuery and decodes the resulting rows into
+// outSlice, which must be a pointer to a slice of structs.
+func (cbCtx *cbContext) DoQueryInto(query string, outSlice interface{}) error {
+	return cbCtx.doQueryInto(context.Background(), query, outSlice)
+}
+
+// DoQueryIntoContext behaves like DoQueryInto but honors ctx's deadline and cancellation.
+func (cbCtx *cbContext) DoQueryIntoContext(ctx context.Context, query string, outSlice interface{}) error {
+	return cbCtx.doQueryInto(ctx, query, outSlice)
+}
+
+func (cbCtx *cbContext) doQueryInto(ctx context.Context, query string, outSlice interface{}) error {
+	result, _, err := cbCtx.doQuery(ctx, query)
+	if err != nil {
+		return err
+	}
+	sv := reflect.ValueOf(outSlice)
+	if sv.Kind() != reflect.Ptr || sv.Elem().Kind() != reflect.Slice {
+		return errors.New("corebosgowslib: DoQueryInto outSlice must be a pointer to a slice")
+	}
+	slice := sv.Elem()
+	elemType := slice.Type().Elem()
+	rows, _ := result.([]interface{})
+	out := reflect.MakeSlice(slice.Type(), 0, len(rows))
+	for _, row := range rows {
+		rec, ok := row.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		elem := reflect.New(elemType)
+		if err := decodeInto(rec, elem.Interface()); err != nil {
+			return err
+		}
+		out = reflect.Append(out, elem.Elem())
+	}
+	slice.Set(out)
+	return nil
+}
+
+// DoCreateFrom adds a new record built from record, mirroring DoCreate but
+// accepting a typed struct instead of a map[string]interface{}.
+func (cbCtx *cbContext) DoCreateFrom(module string, record interface{}) (map[string]interface{}, error) {
+	return cbCtx.doCreateFrom(context.Background(), module, record)
+}
+
+// DoCreateFromContext behaves like DoCreateFrom but honors ctx's deadline and cancellation.
+func (cbCtx *cbContext) DoCreateFromContext(ctx context.Context, module string, record interface{}) (map[string]interface{}, error) {
+	return cbCtx.doCreateFrom(ctx, module, record)
+}
+
+func (cbCtx *cbContext) doCreateFrom(ctx context.Context, module string, record interface{}) (map[string]interface{}, error) {
+	valuemap, err := encodeFrom(record)
+	if err != nil {
+		return make(map[string]interface{}), err
+	}
+	return cbCtx.doCreate(ctx, module, valuemap)
+}
+
+// DoUpdateFrom updates a record built from record, mirroring DoUpdate but
+// accepting a typed struct instead of a map[string]interface{}.
+func (cbCtx *cbContext) DoUpdateFrom(module string, record interface{}) (map[string]interface{}, error) {
+	return cbCtx.doUpdateFrom(context.Background(), module, record)
+}
+
+// DoUpdateFromContext behaves like DoUpdateFrom but honors ctx's deadline and cancellation.
+func (cbCtx *cbContext) DoUpdateFromContext(ctx context.Context, module string, record interface{}) (map[string]interface{}, error) {
+	return cbCtx.doUpdateFrom(ctx, module, record)
+}
+
+func (cbCtx *cbContext) doUpdateFrom(ctx context.Context, module string, record interface{}) (map[string]interface{}, error) {
+	valuemap, err := encodeFrom(record)
+	if err != nil {
+		return make(map[string]interface{}), err
+	}
+	return cbCtx.doUpdate(ctx, module, valuemap)
+}
+
+// fieldName resolves the WS field name for a struct field: the `corebos` tag
+// if present, otherwise the `json` tag, otherwise the Go field name.
+func fieldName(field reflect.StructField) string {
+	if tag, ok := field.Tag.Lookup("corebos"); ok {
+		if name := tagName(tag); name != "" {
+			return name
+		}
+	}
+	if tag, ok := field.Tag.Lookup("json"); ok {
+		if name := tagName(tag); name != "" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+func tagName(tag string) string {
+	for i := 0; i < len(tag); i++ {
+		if tag[i] == ',' {
+			return tag[:i]
+		}
+	}
+	return tag
+}
+
+// decodeInto copies the fields of rec into out, which must be a pointer to a
+// struct.
+func decodeInto(rec map[string]interface{}, out interface{}) error {
+	rv := reflect.ValueOf(out)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return errors.New("corebosgowslib: out must be a pointer to a struct")
+	}
+	sv := rv.Elem()
+	st := sv.Type()
+	for i := 0; i < st.NumField(); i++ {
+		field := st.Field(i)
+		fv := sv.Field(i)
+		if !fv.CanSet() {
+			continue
+		}
+		name := fieldName(field)
+		if name == "-" {
+			continue
+		}
+		raw, ok := rec[name]
+		if !ok || raw == nil {
+			continue
+		}
+		if err := setFieldValue(fv, raw); err != nil {
+			return fmt.Errorf("corebosgowslib: field %s: %w", field.Name, err)
+		}
+	}
+	return nil
+}
+
+// encodeFrom builds the map[string]interface{} the WS API expects out of
+// record, which must be a struct or a pointer to one.
+func encodeFrom(record interface{}) (map[string]interface{}, error) {
+	rv := reflect.ValueOf(record)
+	for rv.Kind() == reflect.Ptr {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, errors.New("corebosgowslib: record must be a struct or pointer to a struct")
+	}
+	st := rv.Type()
+	out := make(map[string]interface{})
+	for i := 0; i < st.NumField(); i++ {
+		field := st.Field(i)
+		fv := rv.Field(i)
+		if !fv.CanInterface() {
+			continue
+		}
+		name := fieldName(field)
+		if name == "-" {
+			continue
+		}
+		if fv.Type() == timeType {
+			t := fv.Interface().(time.Time)
+			if t.IsZero() {
+				continue
+			}
+			out[name] = t.Format(cbTimeLayouts[0])
+			continue
+		}
+		out[name] = fv.Interface()
+	}
+	return out, nil
+}
+
+func setFieldValue(fv reflect.Value, raw interface{}) error {
+	if fv.Type() == timeType {
+		s, ok := raw.(string)
+		if !ok || s == "" {
+			return nil
+		}
+		t, err := parseCbTime(s)
+		if err != nil {
+			return err
+		}
+		fv.Set(reflect.ValueOf(t))
+		return nil
+	}
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(toString(raw))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := toInt64(raw)
+		if err != nil {
+			return err
+		}
+		fv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := toInt64(raw)
+		if err != nil {
+			return err
+		}
+		fv.SetUint(uint64(n))
+	case reflect.Float32, reflect.Float64:
+		n, err := toFloat64(raw)
+		if err != nil {
+			return err
+		}
+		fv.SetFloat(n)
+	case reflect.Bool:
+		b, err := toBool(raw)
+		if err != nil {
+			return err
+		}
+		fv.SetBool(b)
+	default:
+		rawVal := reflect.ValueOf(raw)
+		if rawVal.Type().AssignableTo(fv.Type()) {
+			fv.Set(rawVal)
+		}
+	}
+	return nil
+}
+
+// toString converts a decoded JSON value (string, float64 or bool) to its
+// string representation, since coreBOS itself represents most fields,
+// including numbers, as strings.
+func toString(raw interface{}) string {
+	switch v := raw.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+func toInt64(raw interface{}) (int64, error) {
+	switch v := raw.(type) {
+	case string:
+		if v == "" {
+			return 0, nil
+		}
+		return strconv.ParseInt(v, 10, 64)
+	case float64:
+		return int64(v), nil
+	default:
+		return 0, fmt.Errorf("cannot convert %T to int64", raw)
+	}
+}
+
+func toFloat64(raw interface{}) (float64, error) {
+	switch v := raw.(type) {
+	case string:
+		if v == "" {
+			return 0, nil
+		}
+		return strconv.ParseFloat(v, 64)
+	case float64:
+		return v, nil
+	default:
+		return 0, fmt.Errorf("cannot convert %T to float64", raw)
+	}
+}
+
+func toBool(raw interface{}) (bool, error) {
+	switch v := raw.(type) {
+	case bool:
+		return v, nil
+	case string:
+		if v == "" || v == "0" {
+			return false, nil
+		}
+		return strconv.ParseBool(v)
+	case float64:
+		return v != 0, nil
+	default:
+		return false, fmt.Errorf("cannot convert %T to bool", raw)
+	}
+}
+
+// parseCbTime parses a coreBOS date or datetime string into a time.Time.
+func parseCbTime(s string) (time.Time, error) {
+	var err error
+	for _, layout := range cbTimeLayouts {
+		var t time.Time
+		t, err = time.Parse(layout, s)
+		if err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, err
+}